@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"math/bits"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BitStore is the bit-storage layer behind a BloomFilter. The in-memory
+// implementation (memBitStore) is the default; RedisBitStore lets multiple
+// processes share a single filter for cache-front / dedup use cases without
+// the public BloomFilter.Add/Exists API changing at all.
+type BitStore interface {
+	Set(i uint64)
+	Test(i uint64) bool
+	Len() uint64
+}
+
+// BatchBitStore is an optional capability a BitStore can implement to handle
+// a filter's k indices as one round trip instead of k. BloomFilter.Add and
+// Exists use this automatically when the backing store supports it.
+type BatchBitStore interface {
+	BitStore
+	SetAll(indices []uint64)
+	TestAll(indices []uint64) []bool
+}
+
+// memBitStore is the default BitStore, a packed []uint64 bitset held in
+// process memory.
+type memBitStore struct {
+	words []uint64
+	size  uint64
+}
+
+// newMemBitStore creates a memBitStore large enough for size bits.
+func newMemBitStore(size uint64) *memBitStore {
+	return &memBitStore{
+		words: make([]uint64, (size+63)/64),
+		size:  size,
+	}
+}
+
+func (m *memBitStore) Set(i uint64)       { m.words[i/64] |= 1 << (i % 64) }
+func (m *memBitStore) Test(i uint64) bool { return m.words[i/64]&(1<<(i%64)) != 0 }
+func (m *memBitStore) Len() uint64        { return m.size }
+
+// onesCount returns the number of set bits, used by BloomFilter.EstimatedFillRatio.
+func (m *memBitStore) onesCount() uint64 {
+	var set uint64
+	for _, word := range m.words {
+		set += uint64(bits.OnesCount64(word))
+	}
+	return set
+}
+
+// RedisBitStore backs a BloomFilter with a single Redis string, using
+// SETBIT/GETBIT so multiple processes can share one filter. It implements
+// BatchBitStore so a filter's k indices are pipelined into one MULTI/EXEC
+// round trip per Add/Exists call instead of k round trips.
+type RedisBitStore struct {
+	client *redis.Client
+	key    string
+	size   uint64
+	ttl    time.Duration // 0 means no expiry
+}
+
+// NewRedisBitStore creates a RedisBitStore backed by key on client, sized
+// for size bits. If ttl is non-zero, it is (re)applied to key on every Add.
+func NewRedisBitStore(client *redis.Client, key string, size uint64, ttl time.Duration) *RedisBitStore {
+	return &RedisBitStore{client: client, key: key, size: size, ttl: ttl}
+}
+
+// Set implements BitStore by issuing a single SETBIT. Prefer SetAll when
+// setting more than one index, to get the pipelining benefit.
+func (r *RedisBitStore) Set(i uint64) {
+	r.SetAll([]uint64{i})
+}
+
+// Test implements BitStore by issuing a single GETBIT.
+func (r *RedisBitStore) Test(i uint64) bool {
+	return r.TestAll([]uint64{i})[0]
+}
+
+func (r *RedisBitStore) Len() uint64 { return r.size }
+
+// SetAll pipelines SETBIT for every index into one MULTI/EXEC, plus an
+// EXPIRE if a TTL was configured, instead of one round trip per index.
+//
+// BitStore's methods don't return an error, so a Redis failure here panics
+// rather than silently leaving the filter's bits inconsistent with what the
+// caller believes was added.
+func (r *RedisBitStore) SetAll(indices []uint64) {
+	ctx := context.Background()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, i := range indices {
+			pipe.SetBit(ctx, r.key, int64(i), 1)
+		}
+		if r.ttl > 0 {
+			pipe.Expire(ctx, r.key, r.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// TestAll pipelines GETBIT for every index into one MULTI/EXEC.
+func (r *RedisBitStore) TestAll(indices []uint64) []bool {
+	ctx := context.Background()
+	cmds := make([]*redis.IntCmd, len(indices))
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for idx, i := range indices {
+			cmds[idx] = pipe.GetBit(ctx, r.key, int64(i))
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	results := make([]bool, len(indices))
+	for idx, cmd := range cmds {
+		results[idx] = cmd.Val() == 1
+	}
+	return results
+}
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+// fakeBatchBitStore is a minimal BatchBitStore backed by a memBitStore, used
+// to prove Add/Exists route through SetAll/TestAll instead of the per-index
+// Set/Test loop when the backing store supports batching.
+type fakeBatchBitStore struct {
+	*memBitStore
+	setAllCalls  int
+	testAllCalls int
+	setCalls     int
+	testCalls    int
+}
+
+func newFakeBatchBitStore(size uint64) *fakeBatchBitStore {
+	return &fakeBatchBitStore{memBitStore: newMemBitStore(size)}
+}
+
+func (f *fakeBatchBitStore) Set(i uint64) {
+	f.setCalls++
+	f.memBitStore.Set(i)
+}
+
+func (f *fakeBatchBitStore) Test(i uint64) bool {
+	f.testCalls++
+	return f.memBitStore.Test(i)
+}
+
+func (f *fakeBatchBitStore) SetAll(indices []uint64) {
+	f.setAllCalls++
+	for _, i := range indices {
+		f.memBitStore.Set(i)
+	}
+}
+
+func (f *fakeBatchBitStore) TestAll(indices []uint64) []bool {
+	f.testAllCalls++
+	results := make([]bool, len(indices))
+	for i, index := range indices {
+		results[i] = f.memBitStore.Test(index)
+	}
+	return results
+}
+
+func TestBloomFilterUsesBatchBitStoreWhenAvailable(t *testing.T) {
+	store := newFakeBatchBitStore(1024)
+	bf := NewBloomFilterWithBitStore(1024, defaultHasher, 4, store)
+
+	bf.Add("alpha")
+	if store.setAllCalls != 1 {
+		t.Errorf("SetAll calls = %d, want 1", store.setAllCalls)
+	}
+	if store.setCalls != 0 {
+		t.Errorf("Set calls = %d, want 0 (should route through SetAll, not the per-index loop)", store.setCalls)
+	}
+
+	if _, ok := bf.Exists("alpha"); !ok {
+		t.Error("Exists(\"alpha\") = false, want true")
+	}
+	if store.testAllCalls != 1 {
+		t.Errorf("TestAll calls = %d, want 1", store.testAllCalls)
+	}
+	if store.testCalls != 0 {
+		t.Errorf("Test calls = %d, want 0 (should route through TestAll, not the per-index loop)", store.testCalls)
+	}
+}
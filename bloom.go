@@ -4,86 +4,184 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"sync"
 
 	"github.com/google/uuid"
-	"github.com/spaolacci/murmur3"
 )
 
+// defaultK is the number of bit positions derived per key when a caller
+// does not pick k explicitly (see NewBloomFilter).
+const defaultK uint = 8
+
 type BloomFilter struct {
-	Store    []bool            // to Store Bitset
-	Size     int32             // Size of the filters
-	mHashers []murmur3.Hash128 // HashFunctions to improve the probabilistic accuracy
+	bits   BitStore // bit-storage backend, in-memory by default
+	Size   int32    // Size of the filters, in bits
+	k      uint     // number of bit positions derived per key
+	n      uint     // target capacity this filter was sized for, via NewWithEstimates (0 if unknown)
+	hasher Hasher   // backend used to derive bit positions
 }
 
-// NewBloomFilter helps to create a new bloom.Filter with the given size.
-//
-// It initializes the bloom.Store with the size and generates the
-// murmur3 hash functions with different seeds.
+// NewBloomFilter helps to create a new bloom.Filter with the given size and
+// number of bit positions k to derive per key, using the default murmur3
+// Hasher and an in-memory bit store. Use NewBloomFilterWithHasher or
+// NewBloomFilterWithBitStore to pick a different backend.
 //
-// The number of hash functions is fixed to 8.
+// Instead of running k independent murmur3 hashers, indices are derived
+// from a single Hasher.Sum128 call using the Kirsch-Mitzenmacher
+// double-hashing technique: index_i = (h1 + i*h2) mod size. This gives a
+// false-positive rate indistinguishable from k independent hashes while
+// hashing the key only once, and makes Exists safe for concurrent readers
+// since no hasher state is mutated per call.
 //
 // Returns a new bloom.Filter.
-func NewBloomFilter(size int32) *BloomFilter {
-	// Generating the Seed and MHashers (Murmur 128 style)
-	//
+func NewBloomFilter(size int32, k uint) *BloomFilter {
+	return NewBloomFilterWithHasher(size, defaultHasher, k)
+}
+
+// NewBloomFilterWithHasher creates a new bloom.Filter with the given size
+// and number of bit positions k, deriving bit positions through h instead of
+// the default murmur3 Hasher. Bits are stored in-memory.
+func NewBloomFilterWithHasher(size int32, h Hasher, k uint) *BloomFilter {
+	return NewBloomFilterWithBitStore(size, h, k, newMemBitStore(uint64(size)))
+}
+
+// NewBloomFilterWithBitStore creates a new bloom.Filter backed by store
+// instead of the in-memory default, e.g. a RedisBitStore shared by multiple
+// processes. store must already be sized for size bits.
+func NewBloomFilterWithBitStore(size int32, h Hasher, k uint, store BitStore) *BloomFilter {
 	return &BloomFilter{
-		Store: make([]bool, size),
-		Size:  size,
-		mHashers: []murmur3.Hash128{
-			murmur3.New128WithSeed(uint32(11)),
-			murmur3.New128WithSeed(uint32(31)),
-			murmur3.New128WithSeed(uint32(131)),
-			murmur3.New128WithSeed(uint32(989)),
-			murmur3.New128WithSeed(uint32(1919)),
-			murmur3.New128WithSeed(uint32(2007)),
-			murmur3.New128WithSeed(uint32(31313)),
-			murmur3.New128WithSeed(uint32(9281917)),
-		},
+		bits:   store,
+		Size:   size,
+		k:      k,
+		hasher: h,
+	}
+}
+
+// NewWithEstimates creates a new bloom.Filter sized for a target capacity n
+// and a target false-positive rate fpRate, using the standard bloom filter
+// sizing formulas:
+//
+//	m = ceil(-n * ln(fpRate) / ln(2)^2)
+//	k = round((m / n) * ln(2))
+//
+// n must be greater than 0 and fpRate must be in (0, 1); otherwise the
+// formulas above divide by zero or degenerate to a useless filter, so
+// NewWithEstimates returns an error instead of propagating a panic into
+// hashIndices.
+//
+// Returns a new bloom.Filter sized to keep the false-positive rate at or
+// below fpRate once n keys have been added.
+func NewWithEstimates(n uint, fpRate float64) (*BloomFilter, error) {
+	if n == 0 {
+		return nil, fmt.Errorf("bloom: capacity n must be greater than 0")
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, fmt.Errorf("bloom: fpRate must be in (0, 1), got %v", fpRate)
+	}
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Round((m / float64(n)) * math.Ln2)
+	if k < 1 {
+		k = 1
 	}
+	bf := NewBloomFilter(int32(m), uint(k))
+	bf.n = n
+	return bf, nil
 }
 
 // Info returns the required information for the bloom.Filter configuration
 func (bf *BloomFilter) Info() map[string]any {
 	return map[string]any{
-		"size":           bf.Size,
-		"totalHashFuncs": len(bf.mHashers),
+		"size":   bf.Size,
+		"k":      bf.k,
+		"cap":    bf.n,
+		"hasher": bf.hasher.Name(),
 	}
 }
 
-// ComputeMurmurHash computes and returns the murmur has of a query string `key`
-// you have to module it with the bloom.Size to set the index True in bloom.Store
-//
-// Non-cryptographic hash, fast and efficient and implementation specific.
-func (bf *BloomFilter) ComputeMurmurHash(key string, hashFn int) uint64 {
-	bf.mHashers[hashFn].Write([]byte(key))
-	val, _ := bf.mHashers[hashFn].Sum128()
-	bf.mHashers[hashFn].Reset()
-	return val
+// BitSize returns m, the total number of bits backing the filter.
+func (bf *BloomFilter) BitSize() int32 {
+	return bf.Size
+}
+
+// Cap returns the target capacity n the filter was sized for via
+// NewWithEstimates, or 0 if it was built with NewBloomFilter directly.
+func (bf *BloomFilter) Cap() uint {
+	return bf.n
 }
 
-// Add helps to add add given key into the bloom.Store. Remember it does not store the
+// EstimatedFillRatio returns the fraction of bits currently set. This
+// approaches ln(2) as the filter fills up to its designed false-positive
+// rate. The in-memory bit store answers this via a direct popcount; other
+// BitStore backends fall back to testing every bit.
+func (bf *BloomFilter) EstimatedFillRatio() float64 {
+	var set uint64
+	if mem, ok := bf.bits.(*memBitStore); ok {
+		set = mem.onesCount()
+	} else {
+		for i := uint64(0); i < uint64(bf.Size); i++ {
+			if bf.bits.Test(i) {
+				set++
+			}
+		}
+	}
+	return float64(set) / float64(bf.Size)
+}
+
+// indices derives the bf.k bit positions for key using double-hashing over a
+// single bf.hasher.Sum128 result, per the Kirsch-Mitzenmacher technique.
+func (bf *BloomFilter) indices(key string) []uint64 {
+	return hashIndices(bf.hasher, key, bf.Size, bf.k)
+}
+
+// hashIndices derives k bit positions for key within a filter of the given
+// bit size, using a single h.Sum128 call and the Kirsch-Mitzenmacher
+// double-hashing technique: index_i = (h1 + i*h2) mod size. BloomFilter and
+// CountingBloomFilter both route through this so their indices always agree
+// for the same (hasher, key, size, k).
+func hashIndices(h Hasher, key string, size int32, k uint) []uint64 {
+	h1, h2 := h.Sum128([]byte(key))
+	indices := make([]uint64, k)
+	for i := uint(0); i < k; i++ {
+		indices[i] = (h1 + uint64(i)*h2) % uint64(size)
+	}
+	return indices
+}
+
+// Add helps to add add given key into the bloom filter. Remember it does not store the
 // actual keys. rather it a probabilistic representtal of their presence.
+//
+// If the backing BitStore supports batching (e.g. RedisBitStore), the k
+// indices are written in a single round trip instead of k.
 func (bf *BloomFilter) Add(key string) {
-	// index := bf.ComputeMurmurHash(key) % uint64(bf.Size)
-	// bf.Store[index] = true
-	// Utilizing all has functions
-	for i := 0; i < len(bf.mHashers); i++ {
-		index := bf.ComputeMurmurHash(key, i) % uint64(bf.Size)
-		bf.Store[index] = true
+	indices := bf.indices(key)
+	if batch, ok := bf.bits.(BatchBitStore); ok {
+		batch.SetAll(indices)
+		return
+	}
+	for _, index := range indices {
+		bf.bits.Set(index)
 	}
 }
 
-// Exists helps to lookup if the key present in the bloom.Store.
+// Exists helps to lookup if the key present in the bloom filter.
 // In real, the key might not be present even if the return is true. as it
 // works as a probabilistic estimation of finding the presence.
+//
+// If the backing BitStore supports batching, the k indices are read in a
+// single round trip instead of k.
 func (bf *BloomFilter) Exists(key string) (uint64, bool) {
-	// index := bf.ComputeMurmurHash(key) % uint64(bf.Size)
-	// return index, bf.Store[index]
-
-	for i := 0; i < len(bf.mHashers); i++ {
-		index := bf.ComputeMurmurHash(key, i) % uint64(bf.Size)
-		if !bf.Store[index] {
+	indices := bf.indices(key)
+	if batch, ok := bf.bits.(BatchBitStore); ok {
+		for i, set := range batch.TestAll(indices) {
+			if !set {
+				return indices[i], false
+			}
+		}
+		return 0, true
+	}
+	for _, index := range indices {
+		if !bf.bits.Test(index) {
 			return index, false
 		}
 	}
@@ -95,8 +193,10 @@ var wg sync.WaitGroup
 var testResultsOutChan chan map[string]any
 
 func main() {
-	BloomFilterSize := 100_000
-	testResultsOutChan = make(chan map[string]any, BloomFilterSize)
+	// Target false-positive rates to sweep, driving the filter size via
+	// NewWithEstimates instead of a hard-coded bloomSize loop.
+	targetFPRates := []float64{0.1, 0.05, 0.01, 0.005, 0.001, 0.0005, 0.0001}
+	testResultsOutChan = make(chan map[string]any, len(targetFPRates))
 
 	// Generate a dataset.
 	dataset, trainDataset, testDataset := generateDataset(20_000) // 20K
@@ -105,10 +205,11 @@ func main() {
 	log.Println("total test.dataset size:", len(testDataset))
 	log.Println("invoking the test.cases into goroutines...")
 
-	// Dynamically change the bloomFilter size
-	for bfsize := 1000; bfsize <= BloomFilterSize; bfsize += 10000 {
+	// Sweep the target false-positive rate, sizing each filter for the
+	// train dataset's cardinality.
+	for _, fpRate := range targetFPRates {
 		wg.Add(1)
-		go PerformTests(bfsize, dataset, trainDataset, testDataset)
+		go PerformTests(uint(len(trainDataset)), fpRate, dataset, trainDataset, testDataset)
 	}
 
 	wg.Wait()
@@ -141,10 +242,14 @@ func main() {
 	fmt.Println("========= BEST BLOOM FILTER =========")
 }
 
-func PerformTests(bloomSize int, dataset []string, trainDataset map[string]bool, testDataset map[string]bool) {
+func PerformTests(n uint, fpRate float64, dataset []string, trainDataset map[string]bool, testDataset map[string]bool) {
 	defer wg.Done()
-	// Define a bloom.
-	bloom := NewBloomFilter(int32(bloomSize))
+	// Define a bloom, sized for capacity n at the target false-positive rate.
+	bloom, err := NewWithEstimates(n, fpRate)
+	if err != nil {
+		log.Println("skipping invalid test case:", err)
+		return
+	}
 
 	// Add the keys into bloom.
 	for key := range trainDataset {
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBloomFilterAddExists(t *testing.T) {
+	bf := NewBloomFilter(1024, 4)
+
+	present := []string{"alpha", "bravo", "charlie"}
+	for _, key := range present {
+		bf.Add(key)
+	}
+
+	for _, key := range present {
+		if _, ok := bf.Exists(key); !ok {
+			t.Errorf("Exists(%q) = false, want true (no false negatives allowed)", key)
+		}
+	}
+
+	if _, ok := bf.Exists("definitely-not-added"); ok {
+		t.Log("Exists(\"definitely-not-added\") = true: a false positive, which is allowed but unlikely for a single key in a 1024-bit filter")
+	}
+}
+
+// TestNewWithEstimatesSizing checks BitSize/k against the sizing formulas
+// documented on NewWithEstimates for a known (n, fpRate) pair.
+func TestNewWithEstimatesSizing(t *testing.T) {
+	bf, err := NewWithEstimates(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewWithEstimates: %v", err)
+	}
+	if got, want := bf.BitSize(), int32(9586); got != want {
+		t.Errorf("BitSize() = %d, want %d", got, want)
+	}
+	if got, want := bf.k, uint(7); got != want {
+		t.Errorf("k = %d, want %d", got, want)
+	}
+}
+
+func TestNewWithEstimatesRejectsZeroCapacity(t *testing.T) {
+	if _, err := NewWithEstimates(0, 0.01); err == nil {
+		t.Error("NewWithEstimates(0, 0.01): got nil error, want an error")
+	}
+}
+
+func TestNewWithEstimatesRejectsBadFPRate(t *testing.T) {
+	for _, fpRate := range []float64{0, -0.1, 1, 1.5} {
+		if _, err := NewWithEstimates(100, fpRate); err == nil {
+			t.Errorf("NewWithEstimates(100, %v): got nil error, want an error", fpRate)
+		}
+	}
+}
+
+// TestBloomFilterConcurrentExists exercises the claim in NewBloomFilter's
+// doc comment that double-hashing makes Exists safe for concurrent readers,
+// since indices() no longer mutates per-call hasher state the way the old
+// mHashers slice did.
+func TestBloomFilterConcurrentExists(t *testing.T) {
+	bf := NewBloomFilter(4096, 8)
+	for i := 0; i < 100; i++ {
+		bf.Add(string(rune('a' + i%26)))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				bf.Exists(string(rune('a' + i%26)))
+			}
+		}()
+	}
+	wg.Wait()
+}
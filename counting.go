@@ -0,0 +1,102 @@
+package main
+
+// counterBits is the width of each counter packed into CountingBloomFilter's
+// Store. 4 bits (a nibble) keeps memory close to a plain bitset while still
+// tolerating a handful of repeat inserts of the same key before saturating.
+const counterBits = 4
+const counterMax uint64 = 1<<counterBits - 1 // 15
+const countersPerWord = 64 / counterBits     // 16
+
+// CountingBloomFilter is a BloomFilter that replaces each bit with a small
+// saturating counter, so keys can be removed as well as added. It shares its
+// indexing scheme with BloomFilter via hashIndices.
+type CountingBloomFilter struct {
+	Store  []uint64 // packed 4-bit counters, countersPerWord counters per word
+	Size   int32    // number of counters (m)
+	k      uint     // number of counters touched per key
+	hasher Hasher   // backend used to derive counter positions
+}
+
+// NewCountingBloomFilter creates a CountingBloomFilter with size counters and
+// k counters derived per key, using the default murmur3 Hasher.
+func NewCountingBloomFilter(size int32, k uint) *CountingBloomFilter {
+	return &CountingBloomFilter{
+		Store:  make([]uint64, (size+int32(countersPerWord)-1)/int32(countersPerWord)),
+		Size:   size,
+		k:      k,
+		hasher: defaultHasher,
+	}
+}
+
+// Info returns the required information for the CountingBloomFilter configuration.
+func (cbf *CountingBloomFilter) Info() map[string]any {
+	return map[string]any{
+		"size":   cbf.Size,
+		"k":      cbf.k,
+		"hasher": cbf.hasher.Name(),
+	}
+}
+
+// indices derives the cbf.k counter positions for key, sharing BloomFilter's
+// hashIndices helper so both types agree on layout for the same (size, k).
+func (cbf *CountingBloomFilter) indices(key string) []uint64 {
+	return hashIndices(cbf.hasher, key, cbf.Size, cbf.k)
+}
+
+// counter returns the current value of counter i.
+func (cbf *CountingBloomFilter) counter(i uint64) uint64 {
+	shift := (i % countersPerWord) * counterBits
+	return (cbf.Store[i/countersPerWord] >> shift) & counterMax
+}
+
+// setCounter sets counter i to v (v must fit within counterBits).
+func (cbf *CountingBloomFilter) setCounter(i uint64, v uint64) {
+	shift := (i % countersPerWord) * counterBits
+	idx := i / countersPerWord
+	cbf.Store[idx] = (cbf.Store[idx] &^ (counterMax << shift)) | (v << shift)
+}
+
+// Add increments the k counters for key, saturating each at counterMax.
+func (cbf *CountingBloomFilter) Add(key string) {
+	for _, index := range cbf.indices(key) {
+		if v := cbf.counter(index); v < counterMax {
+			cbf.setCounter(index, v+1)
+		}
+	}
+}
+
+// Remove decrements the k counters for key. A counter sitting at counterMax
+// is left alone instead of decremented: it may have saturated from prior
+// inserts of other keys sharing that slot, so decrementing it could make the
+// filter forget a key that is still present (a false negative).
+func (cbf *CountingBloomFilter) Remove(key string) {
+	for _, index := range cbf.indices(key) {
+		if v := cbf.counter(index); v > 0 && v < counterMax {
+			cbf.setCounter(index, v-1)
+		}
+	}
+}
+
+// Exists reports whether key may be present: true unless any of its k
+// counters is zero, in which case it is definitely absent.
+func (cbf *CountingBloomFilter) Exists(key string) bool {
+	for _, index := range cbf.indices(key) {
+		if cbf.counter(index) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count estimates how many times key has been added, as the minimum of its k
+// counters. Like Exists, this is probabilistic: shared slots can only ever
+// inflate the estimate, never deflate it.
+func (cbf *CountingBloomFilter) Count(key string) uint {
+	min := counterMax
+	for _, index := range cbf.indices(key) {
+		if v := cbf.counter(index); v < min {
+			min = v
+		}
+	}
+	return uint(min)
+}
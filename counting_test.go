@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestCountingBloomFilterAddExistsRemove(t *testing.T) {
+	cbf := NewCountingBloomFilter(1024, 4)
+
+	cbf.Add("alpha")
+	cbf.Add("bravo")
+
+	if !cbf.Exists("alpha") {
+		t.Fatal("Exists(\"alpha\") = false after Add, want true")
+	}
+	if !cbf.Exists("bravo") {
+		t.Fatal("Exists(\"bravo\") = false after Add, want true")
+	}
+
+	cbf.Remove("alpha")
+	if cbf.Exists("alpha") {
+		t.Error("Exists(\"alpha\") = true after Remove, want false")
+	}
+	if !cbf.Exists("bravo") {
+		t.Error("Remove(\"alpha\") should not affect \"bravo\"")
+	}
+}
+
+func TestCountingBloomFilterCount(t *testing.T) {
+	cbf := NewCountingBloomFilter(1024, 4)
+
+	cbf.Add("alpha")
+	cbf.Add("alpha")
+	cbf.Add("alpha")
+
+	if got := cbf.Count("alpha"); got != 3 {
+		t.Errorf("Count(\"alpha\") = %d, want 3", got)
+	}
+
+	cbf.Remove("alpha")
+	if got := cbf.Count("alpha"); got != 2 {
+		t.Errorf("Count(\"alpha\") after one Remove = %d, want 2", got)
+	}
+}
+
+// TestCountingBloomFilterRemoveDoesNotUnderflow checks the saturating
+// decrement: removing a key more times than it was added must not wrap a
+// zero counter below zero, which would corrupt Exists for any other key
+// sharing that slot.
+func TestCountingBloomFilterRemoveDoesNotUnderflow(t *testing.T) {
+	cbf := NewCountingBloomFilter(1024, 4)
+
+	cbf.Add("alpha")
+	cbf.Remove("alpha")
+	cbf.Remove("alpha")
+	cbf.Remove("alpha")
+
+	if cbf.Exists("alpha") {
+		t.Error("Exists(\"alpha\") = true after over-removing, want false")
+	}
+	if got := cbf.Count("alpha"); got != 0 {
+		t.Errorf("Count(\"alpha\") after over-removing = %d, want 0", got)
+	}
+}
+
+// TestCountingBloomFilterSaturatingCounterNotDecremented checks that a
+// counter sitting at counterMax (saturated, so its true count is unknown)
+// is left alone by Remove rather than decremented, per CountingBloomFilter's
+// doc comment.
+func TestCountingBloomFilterSaturatingCounterNotDecremented(t *testing.T) {
+	cbf := NewCountingBloomFilter(1024, 4)
+
+	for i := 0; i < int(counterMax)+5; i++ {
+		cbf.Add("alpha")
+	}
+	for _, index := range cbf.indices("alpha") {
+		if got := cbf.counter(index); got != counterMax {
+			t.Fatalf("counter(%d) = %d, want saturated at %d", index, got, counterMax)
+		}
+	}
+
+	cbf.Remove("alpha")
+	for _, index := range cbf.indices("alpha") {
+		if got := cbf.counter(index); got != counterMax {
+			t.Errorf("counter(%d) = %d after Remove on a saturated counter, want unchanged %d", index, got, counterMax)
+		}
+	}
+}
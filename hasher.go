@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dchest/siphash"
+	"github.com/spaolacci/murmur3"
+)
+
+// Hasher derives a 128-bit hash (as two uint64 halves) from a key. Every
+// BloomFilter's k bit positions come from a single Hasher.Sum128 call via
+// the Kirsch-Mitzenmacher double-hashing technique in hashIndices, so
+// swapping the Hasher trades throughput for properties like keyed
+// hash-flooding resistance without touching Add/Exists.
+type Hasher interface {
+	// Sum128 returns a 128-bit hash of key as two uint64 halves.
+	Sum128(key []byte) (uint64, uint64)
+	// Name identifies the backend, recorded in Info() and in serialized
+	// filters so a loaded filter knows which Hasher to reconstruct.
+	Name() string
+}
+
+// KeyedHasher is a Hasher whose output depends on a secret key, which must
+// therefore be part of a filter's serialized form too - otherwise a loaded
+// filter would silently hash keys differently than the original and
+// disagree with it on every lookup. sipHasher implements this.
+type KeyedHasher interface {
+	Hasher
+	// Key returns the k0/k1 this Hasher was constructed with.
+	Key() (k0, k1 uint64)
+}
+
+// defaultHasher is used by NewBloomFilter and NewWithEstimates when no
+// Hasher is given explicitly.
+var defaultHasher Hasher = murmur3Hasher{}
+
+// murmur3Hasher is the original, non-keyed hash this package has always used.
+// Fast, but an attacker who knows the algorithm can craft colliding keys.
+type murmur3Hasher struct{}
+
+func (murmur3Hasher) Sum128(key []byte) (uint64, uint64) { return murmur3.Sum128(key) }
+func (murmur3Hasher) Name() string                       { return "murmur3" }
+
+// xxhashSalt is mixed in after key when deriving xxhashHasher's second half,
+// so h2 isn't just a truncated view of h1.
+var xxhashSalt = [8]byte{0x9e, 0x37, 0x79, 0xb9, 0x7f, 0x4a, 0x7c, 0x15}
+
+// xxhashHasher uses xxHash64 for the first half and xxHash64 of key+salt for
+// the second, trading a little more throughput than murmur3 for the same
+// non-keyed collision exposure.
+type xxhashHasher struct{}
+
+func (xxhashHasher) Sum128(key []byte) (uint64, uint64) {
+	h1 := xxhash.Sum64(key)
+
+	// Hash key and the salt through a fresh digest rather than
+	// append(key, ...): key is caller-owned and may have spare capacity, so
+	// appending to it could silently alias and overwrite the caller's
+	// backing array.
+	d := xxhash.New()
+	d.Write(key)
+	d.Write(xxhashSalt[:])
+	h2 := d.Sum64()
+
+	return h1, h2
+}
+func (xxhashHasher) Name() string { return "xxhash" }
+
+// sipHasher wraps keyed SipHash-2-4, so two processes using different keys
+// produce unrelated bit positions for the same key. This defeats the
+// hash-flooding attacks murmur3Hasher and xxhashHasher are exposed to when
+// keys come from an untrusted source.
+type sipHasher struct {
+	k0, k1 uint64
+}
+
+// NewSipHasher builds a keyed SipHash Hasher. Callers that accept untrusted
+// keys should supply k0/k1 from a process-local secret rather than a
+// hard-coded constant.
+func NewSipHasher(k0, k1 uint64) Hasher {
+	return sipHasher{k0: k0, k1: k1}
+}
+
+func (s sipHasher) Sum128(key []byte) (uint64, uint64) {
+	return siphash.Hash128(s.k0, s.k1, key)
+}
+func (sipHasher) Name() string { return "siphash" }
+
+// Key implements KeyedHasher, so persist.go can round-trip k0/k1 instead of
+// silently substituting a different key on reload.
+func (s sipHasher) Key() (uint64, uint64) { return s.k0, s.k1 }
+
+// sha256Hasher derives both halves from a single cryptographic SHA-256 sum.
+// Far slower than the non-cryptographic options, but useful when filters
+// must resist a hostile party who can see the hash output.
+type sha256Hasher struct{}
+
+func (sha256Hasher) Sum128(key []byte) (uint64, uint64) {
+	sum := sha256.Sum256(key)
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16])
+	return h1, h2
+}
+func (sha256Hasher) Name() string { return "sha256" }
+
+// hasherByName reconstructs a non-keyed Hasher recorded by Name() in a
+// serialized filter. Keyed hashers go through keyedHasherByName instead, so
+// their k0/k1 round-trip rather than being silently replaced.
+var hasherByName = map[string]Hasher{
+	"murmur3": murmur3Hasher{},
+	"xxhash":  xxhashHasher{},
+	"sha256":  sha256Hasher{},
+}
+
+// keyedHasherByName reconstructs a KeyedHasher recorded by Name() plus the
+// k0/k1 persisted alongside it.
+var keyedHasherByName = map[string]func(k0, k1 uint64) Hasher{
+	"siphash": NewSipHasher,
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+// TestXXHashHasherDoesNotAliasInput guards against a regression where
+// Sum128 derived its second hash via append(key, ...), which can silently
+// write into and alias the caller's backing array when key has spare
+// capacity.
+func TestXXHashHasherDoesNotAliasInput(t *testing.T) {
+	key := make([]byte, 4, 16) // spare capacity, like append would exploit
+	copy(key, "key1")
+	original := append([]byte(nil), key...)
+
+	xxhashHasher{}.Sum128(key)
+
+	if string(key) != string(original) {
+		t.Errorf("Sum128 mutated its input: got %q, want %q", key, original)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// binaryMagic prefixes every serialized BloomFilter so ReadFrom/UnmarshalBinary
+// can refuse to load a file that isn't one of ours, and binaryVersion lets the
+// on-disk format change without breaking filters written by older builds.
+var binaryMagic = [4]byte{'B', 'L', 'M', 'F'}
+
+// binaryVersion 2 added the hasher name, recording which Hasher backend a
+// filter's bit positions were derived with so a loaded filter keeps using
+// it. binaryVersion 3 added the keyed hasher's k0/k1: without them, a
+// reloaded siphash-keyed filter silently hashed with a different key than
+// the original and disagreed with it on every lookup.
+const binaryVersion uint8 = 3
+
+// MarshalBinary encodes the filter as a magic header + version prefix
+// followed by Size, k, n and the packed bit array, so a filter built offline
+// can be snapshotted to disk and reloaded without re-adding every key.
+func (bf *BloomFilter) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if _, err := bf.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a filter previously produced by MarshalBinary.
+func (bf *BloomFilter) UnmarshalBinary(data []byte) error {
+	_, err := bf.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// WriteTo writes the filter to w in the binary format described by
+// MarshalBinary, and implements io.WriterTo.
+func (bf *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	words, err := bf.memWords()
+	if err != nil {
+		return 0, err
+	}
+
+	k0, k1, hasKey := hasherKey(bf.hasher)
+
+	buf := new(bytes.Buffer)
+	buf.Write(binaryMagic[:])
+	buf.WriteByte(binaryVersion)
+	binary.Write(buf, binary.BigEndian, bf.Size)
+	binary.Write(buf, binary.BigEndian, uint32(bf.k))
+	binary.Write(buf, binary.BigEndian, uint32(bf.n))
+	writeString(buf, bf.hasher.Name())
+	buf.WriteByte(boolToByte(hasKey))
+	binary.Write(buf, binary.BigEndian, k0)
+	binary.Write(buf, binary.BigEndian, k1)
+	binary.Write(buf, binary.BigEndian, uint32(len(words)))
+	binary.Write(buf, binary.BigEndian, words)
+
+	n, werr := w.Write(buf.Bytes())
+	return int64(n), werr
+}
+
+// memWords returns the raw packed words behind bf, failing if bf isn't
+// backed by the in-memory BitStore. Only an in-memory filter's bits live
+// locally; a RedisBitStore-backed filter's bits already live in Redis, so
+// there is nothing meaningful to snapshot.
+func (bf *BloomFilter) memWords() ([]uint64, error) {
+	mem, ok := bf.bits.(*memBitStore)
+	if !ok {
+		return nil, fmt.Errorf("bloom: snapshotting is only supported for the in-memory bit store, got %T", bf.bits)
+	}
+	return mem.words, nil
+}
+
+// hasherKey extracts h's k0/k1 if it's a KeyedHasher, so callers can persist
+// them alongside its name instead of letting a reload silently substitute a
+// different key.
+func hasherKey(h Hasher) (k0, k1 uint64, hasKey bool) {
+	if kh, ok := h.(KeyedHasher); ok {
+		k0, k1 = kh.Key()
+		return k0, k1, true
+	}
+	return 0, 0, false
+}
+
+// resolveHasher reconstructs the Hasher recorded by name (and, for a keyed
+// hasher, k0/k1) in a serialized filter.
+func resolveHasher(name string, hasKey bool, k0, k1 uint64) (Hasher, error) {
+	if hasKey {
+		newKeyedHasher, ok := keyedHasherByName[name]
+		if !ok {
+			return nil, fmt.Errorf("bloom: unknown keyed hasher backend %q in snapshot", name)
+		}
+		return newKeyedHasher(k0, k1), nil
+	}
+	hasher, ok := hasherByName[name]
+	if !ok {
+		return nil, fmt.Errorf("bloom: unknown hasher backend %q in snapshot", name)
+	}
+	return hasher, nil
+}
+
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeString writes a length-prefixed string, used for the hasher name.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(byte(len(s)))
+	buf.WriteString(s)
+}
+
+// readString reads a length-prefixed string written by writeString.
+func readString(r io.Reader) (string, error) {
+	var length uint8
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ReadFrom reads a filter written by WriteTo from r, and implements
+// io.ReaderFrom.
+func (bf *BloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	counting := &countingReader{r: r}
+
+	var magic [4]byte
+	var version uint8
+	if _, err := io.ReadFull(counting, magic[:]); err != nil {
+		return counting.n, err
+	}
+	if magic != binaryMagic {
+		return counting.n, fmt.Errorf("bloom: bad magic header %q, not a BloomFilter snapshot", magic)
+	}
+	if err := binary.Read(counting, binary.BigEndian, &version); err != nil {
+		return counting.n, err
+	}
+	if version != binaryVersion {
+		return counting.n, fmt.Errorf("bloom: unsupported snapshot version %d", version)
+	}
+
+	var size int32
+	var k, n, words uint32
+	if err := binary.Read(counting, binary.BigEndian, &size); err != nil {
+		return counting.n, err
+	}
+	if err := binary.Read(counting, binary.BigEndian, &k); err != nil {
+		return counting.n, err
+	}
+	if err := binary.Read(counting, binary.BigEndian, &n); err != nil {
+		return counting.n, err
+	}
+	hasherName, err := readString(counting)
+	if err != nil {
+		return counting.n, err
+	}
+	var hasKeyByte byte
+	var k0, k1 uint64
+	if err := binary.Read(counting, binary.BigEndian, &hasKeyByte); err != nil {
+		return counting.n, err
+	}
+	if err := binary.Read(counting, binary.BigEndian, &k0); err != nil {
+		return counting.n, err
+	}
+	if err := binary.Read(counting, binary.BigEndian, &k1); err != nil {
+		return counting.n, err
+	}
+	hasher, err := resolveHasher(hasherName, hasKeyByte != 0, k0, k1)
+	if err != nil {
+		return counting.n, err
+	}
+	if err := binary.Read(counting, binary.BigEndian, &words); err != nil {
+		return counting.n, err
+	}
+
+	store := make([]uint64, words)
+	if err := binary.Read(counting, binary.BigEndian, store); err != nil {
+		return counting.n, err
+	}
+
+	bf.Size = size
+	bf.k = uint(k)
+	bf.n = uint(n)
+	bf.hasher = hasher
+	bf.bits = &memBitStore{words: store, size: uint64(size)}
+	return counting.n, nil
+}
+
+// countingReader tracks bytes read so ReadFrom can report its io.ReaderFrom
+// count even on a partial read error.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// gobFilter mirrors BloomFilter's fields in exported form so the stdlib gob
+// encoder, which cannot see unexported fields, has something to walk. HasKey,
+// K0 and K1 round-trip a KeyedHasher's secret key so a reloaded filter keeps
+// hashing with the same key rather than silently substituting a different
+// one.
+type gobFilter struct {
+	Size   int32
+	K      uint
+	Cap    uint
+	Hasher string
+	HasKey bool
+	K0     uint64
+	K1     uint64
+	Store  []uint64
+}
+
+// GobEncode implements gob.GobEncoder.
+func (bf *BloomFilter) GobEncode() ([]byte, error) {
+	words, err := bf.memWords()
+	if err != nil {
+		return nil, err
+	}
+	k0, k1, hasKey := hasherKey(bf.hasher)
+	buf := new(bytes.Buffer)
+	err = gob.NewEncoder(buf).Encode(gobFilter{
+		Size:   bf.Size,
+		K:      bf.k,
+		Cap:    bf.n,
+		Hasher: bf.hasher.Name(),
+		HasKey: hasKey,
+		K0:     k0,
+		K1:     k1,
+		Store:  words,
+	})
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder.
+func (bf *BloomFilter) GobDecode(data []byte) error {
+	var g gobFilter
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return err
+	}
+	hasher, err := resolveHasher(g.Hasher, g.HasKey, g.K0, g.K1)
+	if err != nil {
+		return err
+	}
+	bf.Size = g.Size
+	bf.k = g.K
+	bf.n = g.Cap
+	bf.hasher = hasher
+	bf.bits = &memBitStore{words: g.Store, size: uint64(g.Size)}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, using the same field names as
+// gobFilter so the JSON and gob snapshots stay interchangeable.
+func (bf *BloomFilter) MarshalJSON() ([]byte, error) {
+	words, err := bf.memWords()
+	if err != nil {
+		return nil, err
+	}
+	k0, k1, hasKey := hasherKey(bf.hasher)
+	return json.Marshal(gobFilter{
+		Size:   bf.Size,
+		K:      bf.k,
+		Cap:    bf.n,
+		Hasher: bf.hasher.Name(),
+		HasKey: hasKey,
+		K0:     k0,
+		K1:     k1,
+		Store:  words,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (bf *BloomFilter) UnmarshalJSON(data []byte) error {
+	var g gobFilter
+	if err := json.Unmarshal(data, &g); err != nil {
+		return err
+	}
+	hasher, err := resolveHasher(g.Hasher, g.HasKey, g.K0, g.K1)
+	if err != nil {
+		return err
+	}
+	bf.Size = g.Size
+	bf.k = g.K
+	bf.n = g.Cap
+	bf.hasher = hasher
+	bf.bits = &memBitStore{words: g.Store, size: uint64(g.Size)}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func newTestFilter(t *testing.T) *BloomFilter {
+	t.Helper()
+	bf, err := NewWithEstimates(100, 0.01)
+	if err != nil {
+		t.Fatalf("NewWithEstimates: %v", err)
+	}
+	for _, key := range []string{"one", "two", "three", "four"} {
+		bf.Add(key)
+	}
+	return bf
+}
+
+func assertRoundTrip(t *testing.T, original, loaded *BloomFilter) {
+	t.Helper()
+	for _, key := range []string{"one", "two", "three", "four"} {
+		if _, ok := loaded.Exists(key); !ok {
+			t.Errorf("loaded filter lost key %q", key)
+		}
+	}
+	if loaded.Size != original.Size || loaded.k != original.k || loaded.n != original.n {
+		t.Errorf("loaded filter config = {Size:%d k:%d n:%d}, want {Size:%d k:%d n:%d}",
+			loaded.Size, loaded.k, loaded.n, original.Size, original.k, original.n)
+	}
+}
+
+func TestBloomFilterBinaryRoundTrip(t *testing.T) {
+	original := newTestFilter(t)
+
+	data, err := original.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	loaded := &BloomFilter{}
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	assertRoundTrip(t, original, loaded)
+}
+
+func TestBloomFilterWriteToReadFrom(t *testing.T) {
+	original := newTestFilter(t)
+
+	buf := new(bytes.Buffer)
+	if _, err := original.WriteTo(buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	loaded := &BloomFilter{}
+	if _, err := loaded.ReadFrom(buf); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	assertRoundTrip(t, original, loaded)
+}
+
+func TestBloomFilterGobRoundTrip(t *testing.T) {
+	original := newTestFilter(t)
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(original); err != nil {
+		t.Fatalf("gob encode: %v", err)
+	}
+
+	loaded := &BloomFilter{}
+	if err := gob.NewDecoder(buf).Decode(loaded); err != nil {
+		t.Fatalf("gob decode: %v", err)
+	}
+	assertRoundTrip(t, original, loaded)
+}
+
+func TestBloomFilterJSONRoundTrip(t *testing.T) {
+	original := newTestFilter(t)
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	loaded := &BloomFilter{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	assertRoundTrip(t, original, loaded)
+}
+
+// TestBloomFilterSipHashRoundTripKeepsKey guards against a regression where
+// a reloaded siphash-keyed filter silently reconstructed NewSipHasher(0, 0)
+// instead of the original k0/k1, so it disagreed with the original on every
+// lookup.
+func TestBloomFilterSipHashRoundTripKeepsKey(t *testing.T) {
+	original := NewBloomFilterWithHasher(1024, NewSipHasher(0x1234, 0x5678), 4)
+	original.Add("secret-key")
+
+	data, err := original.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	loaded := &BloomFilter{}
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if _, ok := loaded.Exists("secret-key"); !ok {
+		t.Error("loaded siphash filter lost \"secret-key\" after binary round-trip, want it to survive")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(original); err != nil {
+		t.Fatalf("gob encode: %v", err)
+	}
+	loadedGob := &BloomFilter{}
+	if err := gob.NewDecoder(buf).Decode(loadedGob); err != nil {
+		t.Fatalf("gob decode: %v", err)
+	}
+	if _, ok := loadedGob.Exists("secret-key"); !ok {
+		t.Error("loaded siphash filter lost \"secret-key\" after gob round-trip, want it to survive")
+	}
+
+	jsonData, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	loadedJSON := &BloomFilter{}
+	if err := json.Unmarshal(jsonData, loadedJSON); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if _, ok := loadedJSON.Exists("secret-key"); !ok {
+		t.Error("loaded siphash filter lost \"secret-key\" after JSON round-trip, want it to survive")
+	}
+}
+
+func TestBloomFilterReadFromRejectsBadMagic(t *testing.T) {
+	loaded := &BloomFilter{}
+	if _, err := loaded.ReadFrom(bytes.NewReader([]byte("not a bloom filter"))); err == nil {
+		t.Error("ReadFrom with bad magic header: got nil error, want an error")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultGrowthFactor is how much larger each new inner filter's capacity is
+// than the one before it, once the active filter fills up.
+const defaultGrowthFactor = 2.0
+
+// defaultTighteningRatio is how much tighter each new inner filter's target
+// false-positive rate is than the one before it. Tightening by r < 1 on every
+// growth keeps the compounded false-positive rate bounded: since
+// sum(p0 * r^i for i >= 0) = p0/(1-r), the filter never exceeds p0/(1-r)
+// regardless of how many times it grows.
+const defaultTighteningRatio = 0.8
+
+// scaledFilter pairs an inner BloomFilter with the target false-positive
+// rate it was built for, since BloomFilter itself only remembers its
+// capacity (Cap) and bit size, not the fpRate that produced them.
+type scaledFilter struct {
+	*BloomFilter
+	fpRate float64
+}
+
+// ScalableBloomFilter grows as insertions exceed its current capacity,
+// rather than requiring the caller to know the final cardinality up front.
+// It is composed of a slice of inner BloomFilters: Add appends a new, larger
+// filter once the active one approaches its design false-positive rate, and
+// Exists reports true if any inner filter reports the key.
+type ScalableBloomFilter struct {
+	filters []*scaledFilter
+	p0      float64 // overall false-positive rate bound
+	s       float64 // capacity growth factor between inner filters
+	r       float64 // false-positive rate tightening ratio between inner filters
+}
+
+// NewScalableBloomFilter creates a ScalableBloomFilter whose first inner
+// filter is sized for initialCap keys at the overall false-positive bound
+// p0, using the default growth factor and tightening ratio. It returns an
+// error under the same conditions as NewWithEstimates (initialCap must be
+// greater than 0, p0 must be in (0, 1)).
+func NewScalableBloomFilter(initialCap uint, p0 float64) (*ScalableBloomFilter, error) {
+	return NewScalableBloomFilterWithRatios(initialCap, p0, defaultGrowthFactor, defaultTighteningRatio)
+}
+
+// NewScalableBloomFilterWithRatios creates a ScalableBloomFilter with an
+// explicit capacity growth factor s and false-positive tightening ratio r
+// between inner filters. It returns an error if s <= 1 (growth must actually
+// enlarge the next inner filter) or r is outside (0, 1) (r must tighten the
+// false-positive rate, and r >= 1 would grow it without bound instead).
+func NewScalableBloomFilterWithRatios(initialCap uint, p0, s, r float64) (*ScalableBloomFilter, error) {
+	if s <= 1 {
+		return nil, fmt.Errorf("bloom: growth factor s must be greater than 1, got %v", s)
+	}
+	if r <= 0 || r >= 1 {
+		return nil, fmt.Errorf("bloom: tightening ratio r must be in (0, 1), got %v", r)
+	}
+	sbf := &ScalableBloomFilter{
+		p0: p0,
+		s:  s,
+		r:  r,
+	}
+	if err := sbf.addFilter(initialCap, p0); err != nil {
+		return nil, err
+	}
+	return sbf, nil
+}
+
+// addFilter appends a new inner filter sized for n keys at fpRate.
+func (sbf *ScalableBloomFilter) addFilter(n uint, fpRate float64) error {
+	bf, err := NewWithEstimates(n, fpRate)
+	if err != nil {
+		return err
+	}
+	sbf.filters = append(sbf.filters, &scaledFilter{
+		BloomFilter: bf,
+		fpRate:      fpRate,
+	})
+	return nil
+}
+
+// active returns the inner filter currently accepting inserts.
+func (sbf *ScalableBloomFilter) active() *scaledFilter {
+	return sbf.filters[len(sbf.filters)-1]
+}
+
+// Add inserts key into the active inner filter, growing the filter first if
+// the active filter's estimated fill ratio has passed ln(2) - the point at
+// which its actual false-positive rate reaches the rate it was designed for.
+func (sbf *ScalableBloomFilter) Add(key string) {
+	active := sbf.active()
+	if active.EstimatedFillRatio() > math.Ln2 {
+		nextCap := uint(float64(active.Cap()) * sbf.s)
+		nextFPRate := active.fpRate * sbf.r
+		// A valid active filter's Cap() and fpRate are already > 0, and
+		// sbf.s/sbf.r stay positive, so these always satisfy
+		// NewWithEstimates' constraints; an error here means sbf was built
+		// with a malformed growth/tightening ratio.
+		if err := sbf.addFilter(nextCap, nextFPRate); err != nil {
+			panic(fmt.Errorf("bloom: scalable filter failed to grow: %w", err))
+		}
+		active = sbf.active()
+	}
+	active.Add(key)
+}
+
+// Exists reports whether key may have been added, checking every inner
+// filter in turn.
+func (sbf *ScalableBloomFilter) Exists(key string) bool {
+	for _, f := range sbf.filters {
+		if _, ok := f.Exists(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Info returns the required information for the ScalableBloomFilter configuration.
+func (sbf *ScalableBloomFilter) Info() map[string]any {
+	return map[string]any{
+		"p0":              sbf.p0,
+		"growthRatio":     sbf.s,
+		"tighteningRatio": sbf.r,
+		"numFilters":      len(sbf.filters),
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScalableBloomFilterGrowsWhenFull(t *testing.T) {
+	sbf, err := NewScalableBloomFilter(16, 0.1)
+	if err != nil {
+		t.Fatalf("NewScalableBloomFilter: %v", err)
+	}
+
+	if got := len(sbf.filters); got != 1 {
+		t.Fatalf("numFilters before growth = %d, want 1", got)
+	}
+
+	for i := 0; i < 200; i++ {
+		sbf.Add(fmt.Sprintf("key-%d", i))
+	}
+
+	if got := len(sbf.filters); got <= 1 {
+		t.Errorf("numFilters after 200 adds = %d, want more than 1 (should have grown)", got)
+	}
+}
+
+// TestScalableBloomFilterExistsAcrossGrownFilters checks that a key added
+// after growth, and therefore only present in a later inner filter, is still
+// found by Exists, which must check every inner filter in turn.
+func TestScalableBloomFilterExistsAcrossGrownFilters(t *testing.T) {
+	sbf, err := NewScalableBloomFilter(16, 0.1)
+	if err != nil {
+		t.Fatalf("NewScalableBloomFilter: %v", err)
+	}
+
+	for i := 0; i < 200; i++ {
+		sbf.Add(fmt.Sprintf("key-%d", i))
+	}
+	if len(sbf.filters) <= 1 {
+		t.Fatal("filter did not grow; test setup is not exercising multiple inner filters")
+	}
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if !sbf.Exists(key) {
+			t.Errorf("Exists(%q) = false, want true (no false negatives allowed)", key)
+		}
+	}
+}
+
+func TestNewScalableBloomFilterWithRatiosRejectsBadRatios(t *testing.T) {
+	cases := []struct {
+		name string
+		s, r float64
+	}{
+		{"s equal to 1", 1, 0.8},
+		{"s less than 1", 0.5, 0.8},
+		{"r zero", 2, 0},
+		{"r equal to 1", 2, 1},
+		{"r negative", 2, -0.1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewScalableBloomFilterWithRatios(16, 0.1, tc.s, tc.r); err == nil {
+				t.Errorf("NewScalableBloomFilterWithRatios(s=%v, r=%v): got nil error, want an error", tc.s, tc.r)
+			}
+		})
+	}
+}